@@ -1,16 +1,67 @@
 package routine
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"math/rand"
+	"os"
 	"os/exec"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorhill/cronexpr"
 	"github.com/x-mod/errors"
 )
 
+//Progress describes incremental progress of a long-running Executor
+type Progress struct {
+	Current int64
+	Total   int64
+	Message string
+}
+
+type _progress struct{}
+
+//ReportProgress pushes p to the progress channel installed by WithProgress, if
+//any is present in ctx; it is a no-op otherwise and never blocks.
+func ReportProgress(ctx context.Context, p Progress) {
+	if ctx == nil {
+		return
+	}
+	ch := ctx.Value(_progress{})
+	if ch == nil {
+		return
+	}
+	select {
+	case ch.(chan<- Progress) <- p:
+	default:
+	}
+}
+
+//ProgressExecutor struct
+type ProgressExecutor struct {
+	ch   chan<- Progress
+	exec Executor
+}
+
+//WithProgress new, installs ch into the context so exec and its descendants
+//can report progress via ReportProgress
+func WithProgress(ch chan<- Progress, exec Executor) Executor {
+	return &ProgressExecutor{ch: ch, exec: exec}
+}
+
+//Execute implement Executor
+func (p *ProgressExecutor) Execute(ctx context.Context) error {
+	return p.exec.Execute(context.WithValue(ctx, _progress{}, p.ch))
+}
+
 //GuaranteeExecutor struct, make sure of none error return
 type GuaranteeExecutor struct {
 	exec Executor
@@ -43,6 +94,8 @@ func (g *GuaranteeExecutor) Execute(ctx context.Context) (err error) {
 type RetryExecutor struct {
 	retryTimes int
 	exec       Executor
+	backoff    BackoffStrategy
+	retryable  func(error) bool
 }
 
 type _retry struct{}
@@ -66,6 +119,90 @@ func Retry(retry int, exec Executor) Executor {
 	}
 }
 
+//ErrPermanent marks an error as non-retriable; RetryWith and RetryIf fail fast on it
+var ErrPermanent = errors.New("permanent error")
+
+//BackoffStrategy decides how long to wait before the next retry attempt
+type BackoffStrategy interface {
+	//NextDelay returns the delay to wait after attempt (1-based) failed with lastErr,
+	//before the next attempt, and whether a retry should be attempted at all.
+	NextDelay(attempt int, lastErr error) (time.Duration, bool)
+}
+
+type backoffFunc func(attempt int, lastErr error) (time.Duration, bool)
+
+//NextDelay implement BackoffStrategy interface
+func (f backoffFunc) NextDelay(attempt int, lastErr error) (time.Duration, bool) {
+	return f(attempt, lastErr)
+}
+
+//ConstantBackoff waits a fixed duration d between attempts
+func ConstantBackoff(d time.Duration) BackoffStrategy {
+	return backoffFunc(func(attempt int, lastErr error) (time.Duration, bool) {
+		return d, true
+	})
+}
+
+//LinearBackoff grows the delay linearly with the attempt number, capped at max
+func LinearBackoff(base, max time.Duration) BackoffStrategy {
+	return backoffFunc(func(attempt int, lastErr error) (time.Duration, bool) {
+		d := base * time.Duration(attempt)
+		if d > max {
+			d = max
+		}
+		return d, true
+	})
+}
+
+//ExponentialBackoff grows the delay exponentially (base*factor^(attempt-1)), capped at max
+func ExponentialBackoff(base time.Duration, factor float64, max time.Duration) BackoffStrategy {
+	return backoffFunc(func(attempt int, lastErr error) (time.Duration, bool) {
+		d := time.Duration(float64(base) * math.Pow(factor, float64(attempt-1)))
+		if d > max {
+			d = max
+		}
+		return d, true
+	})
+}
+
+//JitteredBackoff applies AWS-style equal jitter on top of strategy: the returned
+//delay is reduced by up to fraction of itself and a random amount within that
+//window is added back, smoothing out retry stampedes.
+func JitteredBackoff(strategy BackoffStrategy, fraction float64) BackoffStrategy {
+	return backoffFunc(func(attempt int, lastErr error) (time.Duration, bool) {
+		d, ok := strategy.NextDelay(attempt, lastErr)
+		if !ok || d <= 0 || fraction <= 0 {
+			return d, ok
+		}
+		jitter := time.Duration(float64(d) * fraction)
+		if jitter <= 0 {
+			return d, ok
+		}
+		return (d - jitter) + time.Duration(rand.Int63n(int64(jitter)+1)), ok
+	})
+}
+
+//RetryWith new, retries exec up to retryTimes, sleeping between attempts as
+//determined by strategy; a context cancellation aborts the wait immediately.
+func RetryWith(retryTimes int, strategy BackoffStrategy, exec Executor) Executor {
+	return &RetryExecutor{
+		retryTimes: retryTimes,
+		exec:       exec,
+		backoff:    strategy,
+	}
+}
+
+//RetryIf new, like RetryWith but stops retrying as soon as pred returns false for
+//the last error (e.g. to fail fast on non-retriable errors)
+func RetryIf(retryTimes int, strategy BackoffStrategy, pred func(error) bool, exec Executor) Executor {
+	return &RetryExecutor{
+		retryTimes: retryTimes,
+		exec:       exec,
+		backoff:    strategy,
+		retryable:  pred,
+	}
+}
+
 //Execute implement Executor interface
 func (retry *RetryExecutor) Execute(ctx context.Context) error {
 	var err error
@@ -73,10 +210,29 @@ func (retry *RetryExecutor) Execute(ctx context.Context) error {
 		retry.retryTimes = 1
 	}
 	for i := 0; i < retry.retryTimes; i++ {
-		if err = retry.exec.Execute(context.WithValue(ctx, _retry{}, i+1)); err != nil {
+		if err = retry.exec.Execute(context.WithValue(ctx, _retry{}, i+1)); err == nil {
+			return nil
+		}
+		if err == ErrPermanent {
+			return err
+		}
+		if retry.retryable != nil {
+			if err == context.Canceled || err == context.DeadlineExceeded || !retry.retryable(err) {
+				return err
+			}
+		}
+		if retry.backoff == nil || i == retry.retryTimes-1 {
 			continue
 		}
-		return nil
+		delay, ok := retry.backoff.NextDelay(i+1, err)
+		if !ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
 	}
 	return err
 }
@@ -116,6 +272,7 @@ func (r *RepeatExecutor) Execute(ctx context.Context) error {
 		if err := r.exec.Execute(context.WithValue(ctx, _repeat{}, repeat)); err != nil {
 			return err
 		}
+		ReportProgress(ctx, Progress{Current: int64(repeat), Total: int64(r.repeatTimes)})
 		if r.repeatInterval > 0 {
 			<-time.After(r.repeatInterval)
 		}
@@ -170,7 +327,8 @@ func (c *CrontabExecutor) Execute(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	next := exp.Next(time.Now())
+	prev := time.Now()
+	next := exp.Next(prev)
 	if next.IsZero() {
 		return ErrNonePlan
 	}
@@ -179,9 +337,13 @@ func (c *CrontabExecutor) Execute(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(next.Sub(time.Now())):
-			if err := c.exec.Execute(context.WithValue(ctx, _crontab{}, next)); err != nil {
+			execCtx := context.WithValue(ctx, _crontab{}, next)
+			execCtx = context.WithValue(execCtx, _crontabPrev{}, prev)
+			if err := c.exec.Execute(execCtx); err != nil {
 				return err
 			}
+			ReportProgress(ctx, Progress{Message: next.String()})
+			prev = next
 			next = exp.Next(time.Now())
 			if next.IsZero() {
 				return ErrNonePlan
@@ -190,24 +352,319 @@ func (c *CrontabExecutor) Execute(ctx context.Context) error {
 	}
 }
 
+type _crontabPrev struct{}
+
+//FromCrontabPrev the crontab fire time previous to the one currently executing;
+//on the first fire it is the time Execute started waiting
+func FromCrontabPrev(ctx context.Context) time.Time {
+	if ctx != nil {
+		prev := ctx.Value(_crontabPrev{})
+		if prev != nil {
+			return prev.(time.Time)
+		}
+	}
+	return time.Time{}
+}
+
+//cronTZPrefix is the leading "CRON_TZ=Zone/Name " marker some cron dialects
+//allow inside the plan string itself, overriding any caller-supplied location
+const cronTZPrefix = "CRON_TZ="
+
+//splitCronTZ strips a leading CRON_TZ= prefix from plan, returning the bare
+//plan and the location it designates; loc is returned unchanged if absent.
+func splitCronTZ(plan string, loc *time.Location) (string, *time.Location) {
+	if !strings.HasPrefix(plan, cronTZPrefix) {
+		return plan, loc
+	}
+	rest := plan[len(cronTZPrefix):]
+	idx := strings.IndexAny(rest, " \t")
+	if idx <= 0 {
+		return plan, loc
+	}
+	tz, err := time.LoadLocation(rest[:idx])
+	if err != nil {
+		return plan, loc
+	}
+	return strings.TrimSpace(rest[idx:]), tz
+}
+
+//CrontabInExecutor struct
+type CrontabInExecutor struct {
+	plan string
+	loc  *time.Location
+	exec Executor
+}
+
+//CrontabIn new, like Crontab but evaluates plan in loc instead of the local zone;
+//plan may also carry a leading "CRON_TZ=Zone/Name " prefix, which takes precedence.
+//A nil loc is treated as time.Local, matching Crontab's behavior.
+func CrontabIn(plan string, loc *time.Location, exec Executor) Executor {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &CrontabInExecutor{
+		plan: plan,
+		loc:  loc,
+		exec: exec,
+	}
+}
+
+//Execute implement Executor
+func (c *CrontabInExecutor) Execute(ctx context.Context) error {
+	plan, loc := splitCronTZ(c.plan, c.loc)
+	exp, err := cronexpr.Parse(plan)
+	if err != nil {
+		return err
+	}
+	prev := time.Now().In(loc)
+	next := exp.Next(prev)
+	if next.IsZero() {
+		return ErrNonePlan
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(next.Sub(time.Now())):
+			execCtx := context.WithValue(ctx, _crontab{}, next)
+			execCtx = context.WithValue(execCtx, _crontabPrev{}, prev)
+			if err := c.exec.Execute(execCtx); err != nil {
+				return err
+			}
+			ReportProgress(ctx, Progress{Message: next.String()})
+			prev = next
+			next = exp.Next(time.Now().In(loc))
+			if next.IsZero() {
+				return ErrNonePlan
+			}
+		}
+	}
+}
+
+//NextFire returns the next time plan fires strictly after from, honoring an
+//optional leading "CRON_TZ=Zone/Name " prefix and evaluating in from's location
+func NextFire(plan string, from time.Time) (time.Time, error) {
+	p, loc := splitCronTZ(plan, from.Location())
+	exp, err := cronexpr.Parse(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := exp.Next(from.In(loc))
+	if next.IsZero() {
+		return time.Time{}, ErrNonePlan
+	}
+	return next, nil
+}
+
+//defaultGracePeriod is how long CommandExecutor waits after SIGTERM before
+//escalating to SIGKILL when its context is cancelled
+const defaultGracePeriod = 5 * time.Second
+
 //CommandExecutor struct
 type CommandExecutor struct {
 	command string
 	args    []string
+
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	env         []string
+	dir         string
+	gracePeriod time.Duration
 }
 
 //Command new
-func Command(cmd string, args ...string) Executor {
+func Command(cmd string, args ...string) *CommandExecutor {
 	return &CommandExecutor{command: cmd, args: args}
 }
 
+//WithStdin sets the child process's stdin
+func (cmd *CommandExecutor) WithStdin(r io.Reader) *CommandExecutor {
+	cmd.stdin = r
+	return cmd
+}
+
+//WithStdout sets the child process's stdout
+func (cmd *CommandExecutor) WithStdout(w io.Writer) *CommandExecutor {
+	cmd.stdout = w
+	return cmd
+}
+
+//WithStderr sets the child process's stderr
+func (cmd *CommandExecutor) WithStderr(w io.Writer) *CommandExecutor {
+	cmd.stderr = w
+	return cmd
+}
+
+//WithEnv sets the child process's environment, replacing the inherited one
+func (cmd *CommandExecutor) WithEnv(env []string) *CommandExecutor {
+	cmd.env = env
+	return cmd
+}
+
+//WithDir sets the child process's working directory
+func (cmd *CommandExecutor) WithDir(dir string) *CommandExecutor {
+	cmd.dir = dir
+	return cmd
+}
+
+//syncWriter guards a single writer shared by stdout and stderr: os/exec copies
+//each stream on its own goroutine, so an aliased writer needs synchronization.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+//Write implement io.Writer
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+//WithCombinedOutput captures the child process's stdout and stderr, combined, into buf
+func (cmd *CommandExecutor) WithCombinedOutput(buf *bytes.Buffer) *CommandExecutor {
+	sw := &syncWriter{w: buf}
+	cmd.stdout = sw
+	cmd.stderr = sw
+	return cmd
+}
+
+//WithGracePeriod overrides how long Execute waits after SIGTERM before
+//escalating to SIGKILL when its context is cancelled; defaults to 5s
+func (cmd *CommandExecutor) WithGracePeriod(d time.Duration) *CommandExecutor {
+	cmd.gracePeriod = d
+	return cmd
+}
+
+//CommandError reports a command's abnormal exit, as surfaced by Report consumers
+type CommandError struct {
+	ExitCode int
+	Signal   os.Signal
+	Stderr   []byte
+}
+
+func (e *CommandError) Error() string {
+	if e.Signal != nil {
+		return fmt.Sprintf("command: signaled %s", e.Signal)
+	}
+	return fmt.Sprintf("command: exit code %d", e.ExitCode)
+}
+
 //Execute implement Executor
 func (cmd *CommandExecutor) Execute(ctx context.Context) error {
-	c := exec.CommandContext(ctx, cmd.command, cmd.args...)
+	c := exec.Command(cmd.command, cmd.args...)
+	c.Stdin = cmd.stdin
+	if cmd.env != nil {
+		c.Env = cmd.env
+	}
+	c.Dir = cmd.dir
+
+	//Only pipe stdout through a scanner when a progress channel is actually
+	//installed; otherwise every Command call pays for an extra goroutine and
+	//risks stalling the child if that goroutine ever falls behind.
+	var stdoutReader *io.PipeReader
+	var stdoutWriter *io.PipeWriter
+	if ctx != nil && ctx.Value(_progress{}) != nil {
+		stdoutReader, stdoutWriter = io.Pipe()
+		if cmd.stdout != nil {
+			c.Stdout = io.MultiWriter(cmd.stdout, stdoutWriter)
+		} else {
+			c.Stdout = stdoutWriter
+		}
+	} else {
+		c.Stdout = cmd.stdout
+	}
+	c.Stderr = cmd.stderr
+
+	var stderrBuf bytes.Buffer
+	if c.Stderr == nil {
+		c.Stderr = &stderrBuf
+	} else {
+		c.Stderr = io.MultiWriter(c.Stderr, &stderrBuf)
+	}
+
 	if err := c.Start(); err != nil {
 		return err
 	}
-	return c.Wait()
+
+	var scanDone chan struct{}
+	if stdoutReader != nil {
+		scanDone = make(chan struct{})
+		go func() {
+			defer close(scanDone)
+			//bufio.Reader.ReadString has no fixed max-token-size, unlike
+			//bufio.Scanner, so a line longer than any fixed limit (or a
+			//binary stream with no newlines at all) still keeps draining
+			//the pipe instead of silently stalling the child's write.
+			reader := bufio.NewReader(stdoutReader)
+			for {
+				line, err := reader.ReadString('\n')
+				if len(line) > 0 {
+					ReportProgress(ctx, Progress{Message: strings.TrimRight(line, "\r\n")})
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.Wait()
+		if stdoutWriter != nil {
+			stdoutWriter.Close()
+		}
+		done <- err
+	}()
+
+	//waitScan blocks until the stdout-scanning goroutine has observed EOF, so
+	//Execute never returns (and callers never close the progress channel)
+	//while that goroutine might still call ReportProgress.
+	waitScan := func() {
+		if scanDone != nil {
+			<-scanDone
+		}
+	}
+
+	grace := cmd.gracePeriod
+	if grace == 0 {
+		grace = defaultGracePeriod
+	}
+
+	select {
+	case err := <-done:
+		waitScan()
+		return wrapCommandErr(err, stderrBuf.Bytes())
+	case <-ctx.Done():
+		c.Process.Signal(syscall.SIGTERM)
+		select {
+		case err := <-done:
+			waitScan()
+			return wrapCommandErr(err, stderrBuf.Bytes())
+		case <-time.After(grace):
+			c.Process.Kill()
+			<-done
+			waitScan()
+			return ctx.Err()
+		}
+	}
+}
+
+func wrapCommandErr(err error, stderr []byte) error {
+	if err == nil {
+		return nil
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return err
+	}
+	ce := &CommandError{ExitCode: exitErr.ExitCode(), Stderr: stderr}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		ce.Signal = ws.Signal()
+	}
+	return ce
 }
 
 //TimeoutExecutor struct
@@ -281,6 +738,426 @@ func (ce *ConcurrentExecutor) Execute(ctx context.Context) error {
 	return nil
 }
 
+//RateLimitExecutor struct, a token-bucket rate limiter
+type RateLimitExecutor struct {
+	rps   float64
+	burst float64
+	exec  Executor
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+//RateLimit new, limits exec to rps invocations per second on average, allowing
+//bursts of up to burst; callers block (respecting ctx) until a token is free
+func RateLimit(rps float64, burst int, exec Executor) Executor {
+	return &RateLimitExecutor{
+		rps:   rps,
+		burst: float64(burst),
+		exec:  exec,
+	}
+}
+
+//Execute implement Executor
+func (rl *RateLimitExecutor) Execute(ctx context.Context) error {
+	if err := rl.wait(ctx); err != nil {
+		return err
+	}
+	return rl.exec.Execute(ctx)
+}
+
+func (rl *RateLimitExecutor) wait(ctx context.Context) error {
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.last.IsZero() {
+		rl.tokens = rl.burst
+	} else if elapsed := now.Sub(rl.last).Seconds(); elapsed > 0 {
+		rl.tokens += rl.rps * elapsed
+		if rl.tokens > rl.burst {
+			rl.tokens = rl.burst
+		}
+	}
+	rl.last = now
+	//Reserve this caller's token immediately, letting tokens go negative, so
+	//concurrent callers each claim a distinct future slot instead of all
+	//computing the same deficit from an unchanged rl.tokens snapshot.
+	rl.tokens--
+	var wait time.Duration
+	if rl.tokens < 0 {
+		wait = time.Duration(-rl.tokens / rl.rps * float64(time.Second))
+	}
+	rl.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		//Abandoning the wait refunds the reserved token; otherwise a cancelled
+		//or timed-out caller would permanently steal a slot's worth of
+		//capacity from the bucket, mirroring x/time/rate's Reservation.Cancel.
+		rl.mu.Lock()
+		rl.tokens++
+		rl.mu.Unlock()
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+//ThrottleExecutor struct, enforces a minimum interval between successive invocations
+type ThrottleExecutor struct {
+	min  time.Duration
+	exec Executor
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+//Throttle new, guarantees at least min between successive calls to exec
+func Throttle(min time.Duration, exec Executor) Executor {
+	return &ThrottleExecutor{min: min, exec: exec}
+}
+
+//Execute implement Executor
+func (th *ThrottleExecutor) Execute(ctx context.Context) error {
+	th.mu.Lock()
+	var wait time.Duration
+	if !th.last.IsZero() {
+		if elapsed := time.Since(th.last); elapsed < th.min {
+			wait = th.min - elapsed
+		}
+	}
+	th.last = time.Now().Add(wait)
+	th.mu.Unlock()
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return th.exec.Execute(ctx)
+}
+
+//dedupCall tracks a single in-flight execution shared by one or more waiters;
+//mu guards waiters, which grows as callers join and is read by FromDedupShared
+//while the underlying exec is still running.
+type dedupCall struct {
+	cancel context.CancelFunc
+	ready  chan struct{}
+	err    error
+
+	mu      sync.Mutex
+	waiters int
+}
+
+func (c *dedupCall) join() {
+	c.mu.Lock()
+	c.waiters++
+	c.mu.Unlock()
+}
+
+//leave removes one waiter and reports whether it was the last
+func (c *dedupCall) leave() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waiters--
+	return c.waiters <= 0
+}
+
+//shared reports whether more than one caller is currently waiting on c
+func (c *dedupCall) shared() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.waiters > 1
+}
+
+type _dedupShared struct{}
+
+//FromDedupShared reports whether the call currently in flight is being waited
+//on by more than one caller, i.e. whether this execution's result is shared
+//rather than observed by its sole (primary) caller. Safe to poll while the
+//wrapped executor is still running, since waiters can join at any time.
+func FromDedupShared(ctx context.Context) bool {
+	if ctx != nil {
+		if c, ok := ctx.Value(_dedupShared{}).(*dedupCall); ok {
+			return c.shared()
+		}
+	}
+	return false
+}
+
+//DedupExecutor struct, a singleflight-style executor keyed by key(ctx)
+type DedupExecutor struct {
+	key  func(context.Context) string
+	exec Executor
+
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+}
+
+//Dedup new, collapses concurrent Executions sharing the same key(ctx) into a
+//single underlying call, broadcasting its result to every waiter
+func Dedup(key func(context.Context) string, exec Executor) Executor {
+	return &DedupExecutor{
+		key:   key,
+		exec:  exec,
+		calls: make(map[string]*dedupCall),
+	}
+}
+
+//Execute implement Executor
+func (d *DedupExecutor) Execute(ctx context.Context) error {
+	k := d.key(ctx)
+
+	d.mu.Lock()
+	if c, ok := d.calls[k]; ok {
+		c.join()
+		d.mu.Unlock()
+		return d.wait(ctx, k, c)
+	}
+	callCtx, cancel := context.WithCancel(context.Background())
+	c := &dedupCall{cancel: cancel, ready: make(chan struct{}), waiters: 1}
+	d.calls[k] = c
+	d.mu.Unlock()
+
+	go func() {
+		c.err = d.exec.Execute(context.WithValue(callCtx, _dedupShared{}, c))
+		close(c.ready)
+		d.mu.Lock()
+		if d.calls[k] == c {
+			delete(d.calls, k)
+		}
+		d.mu.Unlock()
+	}()
+	return d.wait(ctx, k, c)
+}
+
+//wait blocks until c completes or ctx is cancelled; the last waiter to cancel
+//also cancels the shared call's derived context.
+func (d *DedupExecutor) wait(ctx context.Context, k string, c *dedupCall) error {
+	select {
+	case <-c.ready:
+		return c.err
+	case <-ctx.Done():
+		last := c.leave()
+		if last {
+			d.mu.Lock()
+			if d.calls[k] == c {
+				delete(d.calls, k)
+			}
+			d.mu.Unlock()
+			c.cancel()
+		}
+		return ctx.Err()
+	}
+}
+
+//CircuitState represents the current state of a CircuitBreaker
+type CircuitState int
+
+const (
+	//CircuitClosed lets calls through, tracking their outcome
+	CircuitClosed CircuitState = iota
+	//CircuitOpen rejects calls immediately without invoking the wrapped executor
+	CircuitOpen
+	//CircuitHalfOpen allows a bounded number of trial calls to probe recovery
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+//ErrCircuitOpen returned immediately while a CircuitBreaker is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+//CircuitOpts configures a CircuitBreaker
+type CircuitOpts struct {
+	//Window is the span of the rolling failure-ratio window
+	Window time.Duration
+	//Buckets is the number of buckets the window is divided into; defaults to 10
+	Buckets int
+	//FailureThreshold is the failure ratio, in [0,1], that trips the breaker
+	FailureThreshold float64
+	//MinSamples is the minimum number of samples in the window before the
+	//failure ratio is considered meaningful
+	MinSamples int64
+	//CooldownDuration is how long the breaker stays Open before probing again
+	CooldownDuration time.Duration
+	//HalfOpenProbes is the number of concurrent trial calls allowed while HalfOpen
+	HalfOpenProbes int
+}
+
+type circuitBucket struct {
+	start            time.Time
+	success, failure int64
+}
+
+//CircuitMetrics is a point-in-time snapshot of a CircuitBreaker
+type CircuitMetrics struct {
+	State     CircuitState
+	Successes int64
+	Failures  int64
+}
+
+type _circuitState struct{}
+
+//FromCircuitState the CircuitState the current call was admitted under
+func FromCircuitState(ctx context.Context) CircuitState {
+	if ctx != nil {
+		st := ctx.Value(_circuitState{})
+		if st != nil {
+			return st.(CircuitState)
+		}
+	}
+	return CircuitClosed
+}
+
+//CircuitBreakerExecutor struct
+type CircuitBreakerExecutor struct {
+	opts CircuitOpts
+	exec Executor
+
+	mu                sync.Mutex
+	state             CircuitState
+	buckets           []circuitBucket
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSucceeded int
+}
+
+//CircuitBreaker new, trips to Open when the rolling failure ratio over
+//opts.Window reaches opts.FailureThreshold, short-circuiting calls with
+//ErrCircuitOpen until opts.CooldownDuration elapses, then probes recovery
+//via opts.HalfOpenProbes trial calls before closing or reopening.
+func CircuitBreaker(opts CircuitOpts, exec Executor) *CircuitBreakerExecutor {
+	if opts.Buckets == 0 {
+		opts.Buckets = 10
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = 1
+	}
+	return &CircuitBreakerExecutor{
+		opts:    opts,
+		exec:    exec,
+		buckets: make([]circuitBucket, opts.Buckets),
+	}
+}
+
+func (cb *CircuitBreakerExecutor) bucketWidth() time.Duration {
+	width := cb.opts.Window / time.Duration(len(cb.buckets))
+	if width <= 0 {
+		width = time.Nanosecond
+	}
+	return width
+}
+
+func (cb *CircuitBreakerExecutor) currentBucket(now time.Time) *circuitBucket {
+	width := cb.bucketWidth()
+	idx := int(now.UnixNano()/int64(width)) % len(cb.buckets)
+	b := &cb.buckets[idx]
+	if now.Sub(b.start) >= cb.opts.Window {
+		b.start, b.success, b.failure = now, 0, 0
+	}
+	return b
+}
+
+func (cb *CircuitBreakerExecutor) tally(now time.Time) (success, failure int64) {
+	cutoff := now.Add(-cb.opts.Window)
+	for i := range cb.buckets {
+		b := &cb.buckets[i]
+		if b.start.After(cutoff) {
+			success += b.success
+			failure += b.failure
+		}
+	}
+	return
+}
+
+//Execute implement Executor
+func (cb *CircuitBreakerExecutor) Execute(ctx context.Context) error {
+	cb.mu.Lock()
+	now := time.Now()
+	if cb.state == CircuitOpen && now.Sub(cb.openedAt) >= cb.opts.CooldownDuration {
+		cb.state = CircuitHalfOpen
+		cb.halfOpenInFlight = 0
+		cb.halfOpenSucceeded = 0
+	}
+	switch cb.state {
+	case CircuitOpen:
+		cb.mu.Unlock()
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.halfOpenInFlight >= cb.opts.HalfOpenProbes {
+			cb.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		cb.halfOpenInFlight++
+	}
+	state := cb.state
+	cb.mu.Unlock()
+
+	err := cb.exec.Execute(context.WithValue(ctx, _circuitState{}, state))
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if state == CircuitHalfOpen {
+		cb.halfOpenInFlight--
+		if err != nil {
+			cb.state = CircuitOpen
+			cb.openedAt = time.Now()
+			cb.halfOpenSucceeded = 0
+			return err
+		}
+		cb.halfOpenSucceeded++
+		if cb.state == CircuitHalfOpen && cb.halfOpenSucceeded >= cb.opts.HalfOpenProbes {
+			cb.state = CircuitClosed
+			cb.halfOpenSucceeded = 0
+			for i := range cb.buckets {
+				cb.buckets[i] = circuitBucket{}
+			}
+		}
+		return err
+	}
+
+	now = time.Now()
+	b := cb.currentBucket(now)
+	if err != nil {
+		b.failure++
+	} else {
+		b.success++
+	}
+	success, failure := cb.tally(now)
+	if total := success + failure; total >= cb.opts.MinSamples && float64(failure)/float64(total) >= cb.opts.FailureThreshold {
+		cb.state = CircuitOpen
+		cb.openedAt = now
+	}
+	return err
+}
+
+//Metrics returns a snapshot of the breaker's current rolling counts and state
+func (cb *CircuitBreakerExecutor) Metrics() CircuitMetrics {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	success, failure := cb.tally(time.Now())
+	return CircuitMetrics{State: cb.state, Successes: success, Failures: failure}
+}
+
 //ParallelExecutor
 type ParallelExecutor struct {
 	execs []Executor
@@ -308,3 +1185,161 @@ func (pe *ParallelExecutor) Execute(ctx context.Context) error {
 	pe.wg.Wait()
 	return nil
 }
+
+//MultiError aggregates the errors collected from concurrent/parallel branches
+type MultiError struct {
+	errs []error
+}
+
+//Error implement error interface
+func (m *MultiError) Error() string {
+	msgs := make([]string, len(m.errs))
+	for i, e := range m.errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//Unwrap supports errors.Is/errors.As over every aggregated error (Go 1.20+)
+func (m *MultiError) Unwrap() []error {
+	return m.errs
+}
+
+type _parallelIndex struct{}
+
+//FromParallelIndex the branch index of the current call, as set by
+//ConcurrentStrict/ParallelStrict/ParallelN
+func FromParallelIndex(ctx context.Context) int {
+	if ctx != nil {
+		idx := ctx.Value(_parallelIndex{})
+		if idx != nil {
+			return idx.(int)
+		}
+	}
+	return -1
+}
+
+//ConcurrentStrictExecutor struct
+type ConcurrentStrictExecutor struct {
+	concurrent int
+	exec       Executor
+	failFast   bool
+}
+
+//ConcurrentStrict new, like Concurrent but collects each goroutine's error into
+//a MultiError instead of logging and discarding it
+func ConcurrentStrict(c int, exec Executor) *ConcurrentStrictExecutor {
+	return &ConcurrentStrictExecutor{concurrent: c, exec: exec}
+}
+
+//FailFast cancels sibling goroutines' context as soon as one of them errors
+func (ce *ConcurrentStrictExecutor) FailFast() *ConcurrentStrictExecutor {
+	ce.failFast = true
+	return ce
+}
+
+//Execute implement Executor
+func (ce *ConcurrentStrictExecutor) Execute(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for i := 0; i < ce.concurrent; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := ce.exec.Execute(context.WithValue(runCtx, _parallelIndex{}, i)); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				if ce.failFast {
+					cancel()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{errs: errs}
+}
+
+//ParallelStrictExecutor struct
+type ParallelStrictExecutor struct {
+	execs    []Executor
+	failFast bool
+}
+
+//ParallelStrict new, like Parallel but collects each branch's error into a
+//MultiError instead of logging and discarding it
+func ParallelStrict(execs ...Executor) *ParallelStrictExecutor {
+	return &ParallelStrictExecutor{execs: execs}
+}
+
+//FailFast cancels sibling branches' context as soon as one of them errors
+func (pe *ParallelStrictExecutor) FailFast() *ParallelStrictExecutor {
+	pe.failFast = true
+	return pe
+}
+
+//Execute implement Executor
+func (pe *ParallelStrictExecutor) Execute(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for i, exec := range pe.execs {
+		wg.Add(1)
+		go func(i int, exec Executor) {
+			defer wg.Done()
+			if err := exec.Execute(context.WithValue(runCtx, _parallelIndex{}, i)); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				if pe.failFast {
+					cancel()
+				}
+			}
+		}(i, exec)
+	}
+	wg.Wait()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{errs: errs}
+}
+
+//ParallelNExecutor struct
+type ParallelNExecutor struct {
+	n     int
+	execs []Executor
+}
+
+//ParallelN new, like Parallel but bounds concurrency to n regardless of len(execs)
+func ParallelN(n int, execs ...Executor) Executor {
+	return &ParallelNExecutor{n: n, execs: execs}
+}
+
+//Execute implement Executor
+func (pe *ParallelNExecutor) Execute(ctx context.Context) error {
+	sem := make(chan struct{}, pe.n)
+	var wg sync.WaitGroup
+	for i, exec := range pe.execs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, exec Executor) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := exec.Execute(context.WithValue(ctx, _parallelIndex{}, i)); err != nil {
+				log.Println("parallel ", i, " failed:", err)
+			}
+		}(i, exec)
+	}
+	wg.Wait()
+	return nil
+}
@@ -1,7 +1,10 @@
 package routine
 
 import (
+	"bytes"
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,6 +34,24 @@ func TestExecutor_Execute(t *testing.T) {
 		return errors.Errorf("%d", FromRetry(ctx))
 	})).Execute(context.TODO()))
 
+	//RetryWith backoff
+	assert.Equal(t, errors.New("3"), RetryWith(3, ConstantBackoff(time.Millisecond), ExecutorFunc(func(ctx context.Context) error {
+		return errors.Errorf("%d", FromRetry(ctx))
+	})).Execute(context.TODO()))
+	assert.Nil(t, RetryWith(5, JitteredBackoff(ExponentialBackoff(time.Millisecond, 2, 20*time.Millisecond), 0.5), ExecutorFunc(func(ctx context.Context) error {
+		if FromRetry(ctx) < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})).Execute(context.TODO()))
+
+	//RetryIf skips non-retriable errors
+	assert.Equal(t, ErrPermanent, RetryIf(3, ConstantBackoff(time.Millisecond), func(err error) bool {
+		return err != ErrPermanent
+	}, ExecutorFunc(func(ctx context.Context) error {
+		return ErrPermanent
+	})).Execute(context.TODO()))
+
 	//Repeat
 	assert.Equal(t, nil, Repeat(3, 10*time.Millisecond, ExecutorFunc(func(ctx context.Context) error {
 		Info(ctx, FromRepeat(ctx))
@@ -43,6 +64,44 @@ func TestExecutor_Execute(t *testing.T) {
 		return nil
 	})).Execute(context.TODO()))
 
+	//ConcurrentStrict
+	err := ConcurrentStrict(3, ExecutorFunc(func(ctx context.Context) error {
+		return errors.Errorf("branch %d", FromParallelIndex(ctx))
+	})).Execute(context.TODO())
+	assert.NotNil(t, err)
+	merr, merrOK := err.(*MultiError)
+	assert.True(t, merrOK)
+	assert.Equal(t, 3, len(merr.errs))
+
+	//ParallelStrict with FailFast
+	assert.NotNil(t, ParallelStrict(
+		ExecutorFunc(func(ctx context.Context) error { return errors.New("boom") }),
+		ExecutorFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	).FailFast().Execute(context.TODO()))
+
+	//ParallelN bounds concurrency
+	var inFlight, maxInFlight int32
+	var pnMu sync.Mutex
+	pnExecs := make([]Executor, 5)
+	for i := 0; i < 5; i++ {
+		pnExecs[i] = ExecutorFunc(func(ctx context.Context) error {
+			n := atomic.AddInt32(&inFlight, 1)
+			pnMu.Lock()
+			if n > int32(maxInFlight) {
+				maxInFlight = n
+			}
+			pnMu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return nil
+		})
+	}
+	assert.Nil(t, ParallelN(2, pnExecs...).Execute(context.TODO()))
+	assert.True(t, maxInFlight <= 2)
+
 	//Crontab
 	timeoutCtx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*100)
 	defer cancel()
@@ -50,9 +109,157 @@ func TestExecutor_Execute(t *testing.T) {
 		return nil
 	})).Execute(timeoutCtx))
 
+	//CrontabIn / NextFire
+	timeoutCtx2, cancel2 := context.WithTimeout(context.TODO(), time.Millisecond*100)
+	defer cancel2()
+	assert.NotNil(t, CrontabIn("* * * * *", time.UTC, ExecutorFunc(func(ctx context.Context) error {
+		return nil
+	})).Execute(timeoutCtx2))
+
+	//FromCrontabPrev surfaces the fire time installed by CrontabExecutor/CrontabInExecutor
+	prevFire := time.Unix(1700000000, 0)
+	assert.Equal(t, prevFire, FromCrontabPrev(context.WithValue(context.TODO(), _crontabPrev{}, prevFire)))
+	assert.True(t, FromCrontabPrev(context.TODO()).IsZero())
+
+	next, err := NextFire("CRON_TZ=America/Los_Angeles 0 3 * * *", time.Now())
+	assert.Nil(t, err)
+	assert.True(t, next.After(time.Now()))
+
+	//CrontabIn treats a nil location as time.Local instead of panicking
+	timeoutCtx3, cancel3 := context.WithTimeout(context.TODO(), time.Millisecond*100)
+	defer cancel3()
+	assert.NotNil(t, CrontabIn("* * * * *", nil, ExecutorFunc(func(ctx context.Context) error {
+		return nil
+	})).Execute(timeoutCtx3))
+
+	//RateLimit
+	var rlCount int
+	assert.Nil(t, RateLimit(1000, 2, ExecutorFunc(func(ctx context.Context) error {
+		rlCount++
+		return nil
+	})).Execute(context.TODO()))
+	assert.Equal(t, 1, rlCount)
+
+	//Throttle
+	throttled := Throttle(5*time.Millisecond, ExecutorFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	start := time.Now()
+	assert.Nil(t, throttled.Execute(context.TODO()))
+	assert.Nil(t, throttled.Execute(context.TODO()))
+	assert.True(t, time.Since(start) >= 5*time.Millisecond)
+
+	//Dedup
+	var dedupCalls int32
+	var sawShared int32
+	dedup := Dedup(func(context.Context) string { return "k" }, ExecutorFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&dedupCalls, 1)
+		time.Sleep(10 * time.Millisecond)
+		if FromDedupShared(ctx) {
+			atomic.StoreInt32(&sawShared, 1)
+		}
+		return nil
+	}))
+	var dedupWg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		dedupWg.Add(1)
+		go func() {
+			defer dedupWg.Done()
+			assert.Nil(t, dedup.Execute(context.TODO()))
+		}()
+	}
+	dedupWg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dedupCalls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sawShared))
+
+	//Dedup reports not-shared when there is a single caller
+	solo := Dedup(func(context.Context) string { return "solo" }, ExecutorFunc(func(ctx context.Context) error {
+		assert.False(t, FromDedupShared(ctx))
+		return nil
+	}))
+	assert.Nil(t, solo.Execute(context.TODO()))
+
+	//CircuitBreaker
+	var sawCircuitState CircuitState
+	breaker := CircuitBreaker(CircuitOpts{
+		Window:           100 * time.Millisecond,
+		FailureThreshold: 0.5,
+		MinSamples:       2,
+		CooldownDuration: 10 * time.Millisecond,
+		HalfOpenProbes:   1,
+	}, ExecutorFunc(func(ctx context.Context) error {
+		sawCircuitState = FromCircuitState(ctx)
+		return errors.New("fail")
+	}))
+	assert.NotNil(t, breaker.Execute(context.TODO()))
+	assert.Equal(t, CircuitClosed, sawCircuitState)
+	assert.NotNil(t, breaker.Execute(context.TODO()))
+	assert.Equal(t, ErrCircuitOpen, breaker.Execute(context.TODO()))
+	assert.Equal(t, CircuitOpen, breaker.Metrics().State)
+
+	//CircuitBreaker recovers through HalfOpen even with HalfOpenProbes left unset
+	var healthy int32
+	recoverer := CircuitBreaker(CircuitOpts{
+		Window:           100 * time.Millisecond,
+		FailureThreshold: 0.5,
+		MinSamples:       2,
+		CooldownDuration: 5 * time.Millisecond,
+	}, ExecutorFunc(func(ctx context.Context) error {
+		if atomic.LoadInt32(&healthy) == 0 {
+			return errors.New("fail")
+		}
+		return nil
+	}))
+	assert.NotNil(t, recoverer.Execute(context.TODO()))
+	assert.NotNil(t, recoverer.Execute(context.TODO()))
+	assert.Equal(t, CircuitOpen, recoverer.Metrics().State)
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, recoverer.Execute(context.TODO()))
+	assert.Equal(t, CircuitClosed, recoverer.Metrics().State)
+
+	//CircuitBreaker requires HalfOpenProbes sequential successes before closing
+	probes := CircuitBreaker(CircuitOpts{
+		Window:           100 * time.Millisecond,
+		FailureThreshold: 0.5,
+		MinSamples:       2,
+		CooldownDuration: 5 * time.Millisecond,
+		HalfOpenProbes:   2,
+	}, ExecutorFunc(func(ctx context.Context) error {
+		if atomic.LoadInt32(&healthy) == 0 {
+			return errors.New("fail")
+		}
+		return nil
+	}))
+	atomic.StoreInt32(&healthy, 0)
+	assert.NotNil(t, probes.Execute(context.TODO()))
+	assert.NotNil(t, probes.Execute(context.TODO()))
+	assert.Equal(t, CircuitOpen, probes.Metrics().State)
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, probes.Execute(context.TODO()))
+	assert.Equal(t, CircuitHalfOpen, probes.Metrics().State)
+	assert.Nil(t, probes.Execute(context.TODO()))
+	assert.Equal(t, CircuitClosed, probes.Metrics().State)
+
 	//Command
 	assert.Equal(t, nil, Command("echo", "hello").Execute(context.TODO()))
 
+	//Command with options
+	var out bytes.Buffer
+	assert.Nil(t, Command("echo", "hi").WithStdout(&out).Execute(context.TODO()))
+	assert.Equal(t, "hi\n", out.String())
+	falseErr := Command("false").Execute(context.TODO())
+	assert.NotNil(t, falseErr)
+	_, ok := falseErr.(*CommandError)
+	assert.True(t, ok)
+
+	//Command with combined output from both streams
+	var combined bytes.Buffer
+	assert.Nil(t, Command("sh", "-c", "echo out; echo err 1>&2").WithCombinedOutput(&combined).Execute(context.TODO()))
+	assert.Contains(t, combined.String(), "out")
+	assert.Contains(t, combined.String(), "err")
+
 	//Timeout
 	assert.Nil(t, Timeout(10*time.Millisecond, ExecutorFunc(func(ctx context.Context) error {
 		time.Sleep(5 * time.Millisecond)
@@ -73,6 +280,44 @@ func TestExecutor_Execute(t *testing.T) {
 		return nil
 	})).Execute(context.TODO()))
 
+	//WithProgress over Repeat
+	progress := make(chan Progress, 3)
+	assert.Nil(t, WithProgress(progress, Repeat(3, 0, ExecutorFunc(func(ctx context.Context) error {
+		return nil
+	}))).Execute(context.TODO()))
+	close(progress)
+	var last Progress
+	for p := range progress {
+		last = p
+	}
+	assert.Equal(t, Progress{Current: 3, Total: 3}, last)
+
+	//WithProgress over Command streams stdout lines as Messages
+	cmdProgress := make(chan Progress, 2)
+	assert.Nil(t, WithProgress(cmdProgress, Command("sh", "-c", "echo one; echo two")).Execute(context.TODO()))
+	close(cmdProgress)
+	var msgs []string
+	for p := range cmdProgress {
+		msgs = append(msgs, p.Message)
+	}
+	assert.Equal(t, []string{"one", "two"}, msgs)
+
+	//Command without a progress channel never blocks on an over-long line
+	longLineCtx, longLineCancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer longLineCancel()
+	assert.Nil(t, Command("sh", "-c", "head -c 200000 /dev/zero | tr '\\0' 'a'; echo; echo done").Execute(longLineCtx))
+
+	//...nor does it block when a progress channel IS installed and drained
+	longLineProgress := make(chan Progress, 8)
+	longLineProgressCtx, longLineProgressCancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer longLineProgressCancel()
+	go func() {
+		for range longLineProgress {
+		}
+	}()
+	assert.Nil(t, WithProgress(longLineProgress, Command("sh", "-c", "head -c 200000 /dev/zero | tr '\\0' 'a'; echo; echo done")).Execute(longLineProgressCtx))
+	close(longLineProgress)
+
 	//Report
 	res1 := make(chan *Result, 1)
 	assert.Nil(t, Report(res1, ExecutorFunc(func(context.Context) error {